@@ -18,8 +18,106 @@ const (
 // unsafeReflectValue typically converts the passed reflect.Value into a one
 // that bypasses the typical safety restrictions preventing access to
 // unaddressable and unexported data.  However, doing this relies on access to
-// the unsafe package.  This is a stub version which simply returns the passed
-// reflect.Value when the unsafe package is not available.
+// the unsafe package and is therefore not possible in this build.  Instead,
+// this stub version falls back to a handful of best-effort strategies that
+// recover most of the same values without it:
+//
+//  1. If v is already addressable and exported, there is nothing to work
+//     around and it is returned unmodified.
+//  2. Otherwise, v.Interface() is attempted under recover(), since it
+//     panics rather than erroring on unexported values.  Success is
+//     re-wrapped with reflect.ValueOf.  This recovers unaddressable-but-
+//     exported values, such as interface elements obtained by ranging over
+//     a slice of interfaces.
+//  3. If v is a struct, a shadow copy is built from its exported fields
+//     (via reflect.VisibleFields) so the dumper can still recurse into the
+//     parts it is already allowed to see.  Unexported fields in the shadow
+//     stay zero valued, as do exported fields reached through a named
+//     unexported parent field (reflect's read-only flag is sticky there
+//     and blocks every descendant regardless of its own exportedness);
+//     only unsafe can populate those.  See safeStructShadow for the
+//     embedded-field case, which fares better.
+//  4. If none of the above apply, the original value is returned so
+//     callers see the previous placeholder behavior rather than a panic.
+//
+// Other callers needing only a stable identity for cycle detection -
+// rather than the value itself - should prefer safePointerIdentity, which
+// covers pointer-like kinds without needing any of the above.
 func unsafeReflectValue(v reflect.Value) reflect.Value {
+	if v.CanAddr() && v.CanInterface() {
+		return v
+	}
+
+	if i, ok := safeInterface(v); ok {
+		return reflect.ValueOf(i)
+	}
+
+	if v.Kind() == reflect.Struct {
+		if shadow, ok := safeStructShadow(v); ok {
+			return shadow
+		}
+	}
+
 	return v
 }
+
+// safeInterface calls v.Interface(), recovering from the panic raised for
+// unexported values so it can be used as a probe instead of a hard failure.
+func safeInterface(v reflect.Value) (i interface{}, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return v.Interface(), true
+}
+
+// safeStructShadow builds a copy of v with the same type and field names,
+// populating whichever exported fields turn out to be readable and
+// leaving the rest - unexported fields, and exported fields that still
+// aren't readable - at their zero value. It reports false only when v's
+// type has no field reflect.VisibleFields considers exported at all, so
+// callers fall back to the original value instead of handing back a
+// struct with no usable shape whatsoever.
+//
+// "Declared exported" and "readable" are not the same thing here. A field
+// reached through a named (non-embedded) unexported parent field, such as
+// v itself when the caller is the unexported "inner" field of some outer
+// struct, carries reflect's sticky read-only flag: that flag propagates
+// to every one of v's descendants regardless of their own exportedness,
+// and both Value.Interface and Value.Set refuse to touch it. There is no
+// safe way to clear it short of the unsafe package this file exists to
+// avoid. An embedded (anonymous) unexported field's read-only flag is not
+// sticky in the same way, so its exported descendants usually remain
+// readable; named unexported fields do not get that benefit. Gating on
+// field.PkgPath (declared exported-ness) rather than fv.CanInterface()
+// lets the shadow still carry the right field names and types for the
+// unreadable case, instead of collapsing to a single opaque placeholder.
+func safeStructShadow(v reflect.Value) (reflect.Value, bool) {
+	if !v.IsValid() {
+		return reflect.Value{}, false
+	}
+
+	shadow := reflect.New(v.Type()).Elem()
+	anyExported := false
+	for _, field := range reflect.VisibleFields(v.Type()) {
+		if field.PkgPath != "" && !field.Anonymous {
+			// Unexported, non-embedded field; reading it requires unsafe.
+			continue
+		}
+		anyExported = true
+
+		fv := v.FieldByIndex(field.Index)
+		if !fv.CanInterface() {
+			// Declared exported, but still unreadable because v's own
+			// read-only flag propagated to it; leave it zero valued
+			// rather than panicking on Set, which also checks this flag.
+			continue
+		}
+		shadow.FieldByIndex(field.Index).Set(fv)
+	}
+	if !anyExported {
+		return reflect.Value{}, false
+	}
+	return shadow, true
+}
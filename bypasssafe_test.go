@@ -0,0 +1,125 @@
+//go:build js || appengine || safe || disableunsafe || !go1.4
+
+package spew
+
+import (
+	"reflect"
+	"testing"
+)
+
+type bypassSafeExported struct {
+	Name string
+	age  int
+}
+
+// TestUnsafeReflectValueExported ensures an already addressable, exported
+// value is returned unmodified rather than going through any of the
+// fallback strategies.
+func TestUnsafeReflectValueExported(t *testing.T) {
+	v := reflect.ValueOf(&bypassSafeExported{Name: "foo"}).Elem().Field(0)
+	got := unsafeReflectValue(v)
+	if got.Interface().(string) != "foo" {
+		t.Errorf("got %v, want foo", got.Interface())
+	}
+}
+
+// TestUnsafeReflectValueInterfaceElem exercises the safeInterface fallback
+// using an unaddressable-but-exported value, the shape produced when
+// ranging over a slice of interfaces.
+func TestUnsafeReflectValueInterfaceElem(t *testing.T) {
+	s := []interface{}{42}
+	v := reflect.ValueOf(s[0])
+	got := unsafeReflectValue(v)
+	if got.Interface().(int) != 42 {
+		t.Errorf("got %v, want 42", got.Interface())
+	}
+}
+
+type bypassSafeInner struct {
+	Name string
+	age  int
+}
+
+type bypassSafeOuter struct {
+	inner bypassSafeInner
+}
+
+// TestUnsafeReflectValueUnexportedField exercises the realistic call
+// site for the struct-shadow fallback: a struct value reached through a
+// named (non-embedded) unexported parent field, exactly what
+// unsafeReflectValue receives when the dumper walks into such a field.
+// reflect's read-only flag is sticky in this case, so even the exported
+// "Name" field stays unreadable - the fallback can only recover the
+// shadow's shape (type and field names), not its data, without unsafe.
+func TestUnsafeReflectValueUnexportedField(t *testing.T) {
+	outer := bypassSafeOuter{inner: bypassSafeInner{Name: "bar", age: 30}}
+	parent := reflect.ValueOf(outer).Field(0)
+	if parent.CanInterface() {
+		t.Fatal("test setup invalid: parent should not be directly interfaceable")
+	}
+
+	got := unsafeReflectValue(parent)
+	if !got.CanInterface() {
+		t.Fatalf("expected a usable (if data-less) shadow value")
+	}
+	if got.Kind() != reflect.Struct {
+		t.Fatalf("got kind %v, want struct", got.Kind())
+	}
+	if name := got.FieldByName("Name").Interface().(string); name != "" {
+		t.Errorf("exported field reached through a named unexported parent cannot carry real data without unsafe; got %q", name)
+	}
+}
+
+type bypassSafeEmbedded struct {
+	Name string
+}
+
+type bypassSafeEmbedOuter struct {
+	bypassSafeEmbedded
+}
+
+// TestUnsafeReflectValueEmbeddedUnexportedField is
+// TestUnsafeReflectValueUnexportedField's counterpart for an embedded
+// (anonymous) unexported field, where reflect's read-only flag does not
+// propagate to exported descendants the same way, so the shadow copy can
+// recover real data rather than just the shape.
+func TestUnsafeReflectValueEmbeddedUnexportedField(t *testing.T) {
+	outer := bypassSafeEmbedOuter{bypassSafeEmbedded{Name: "baz"}}
+	parent := reflect.ValueOf(outer).Field(0)
+	if parent.CanInterface() {
+		t.Fatal("test setup invalid: parent should not be directly interfaceable")
+	}
+
+	got := unsafeReflectValue(parent)
+	if name := got.FieldByName("Name").Interface().(string); name != "baz" {
+		t.Errorf("got %q, want baz", name)
+	}
+}
+
+// TestSafeStructShadowAllUnexported ensures a struct with nothing readable
+// falls through to the original value instead of a misleading all-zero
+// shadow.
+func TestSafeStructShadowAllUnexported(t *testing.T) {
+	type allUnexported struct {
+		name string
+	}
+	v := reflect.ValueOf(allUnexported{name: "hidden"})
+	if _, ok := safeStructShadow(v); ok {
+		t.Errorf("expected no shadow for a struct with no readable fields")
+	}
+}
+
+// TestSafePointerIdentity ensures the supported pointer-like kinds report a
+// stable, non-zero identity usable for cycle detection.
+func TestSafePointerIdentity(t *testing.T) {
+	n := 5
+	p := &n
+	addr, ok := safePointerIdentity(reflect.ValueOf(p))
+	if !ok || addr == 0 {
+		t.Errorf("got addr=%v ok=%v, want a non-zero address", addr, ok)
+	}
+
+	if _, ok := safePointerIdentity(reflect.ValueOf(n)); ok {
+		t.Errorf("expected no identity for a non-pointer-like kind")
+	}
+}
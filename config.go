@@ -0,0 +1,69 @@
+package spew
+
+import "reflect"
+
+// ConfigState houses configuration options that can be used to modify the
+// output format of Dump and Sdump.  A ConfigState value can be constructed
+// and used independently of package-level methods and the default Config
+// tracked by this package, or the convenience methods on the default
+// Config, such as spew.Dump, can be used which rely on this same state.
+type ConfigState struct {
+	// Indent specifies the string to use for each indentation level.  The
+	// global config instance that all top-level functions use set this to a
+	// single space by default.  If you would like more indentation, you
+	// might set this to a tab with "\t" or perhaps two spaces with "  ".
+	Indent string
+
+	// MaxDepth controls the maximum number of levels to descend into nested
+	// data structures.  The default, 0, means there is no limit.
+	MaxDepth int
+
+	// DisableMethods specifies whether or not error and Stringer interfaces
+	// are invoked for types that implement them.
+	DisableMethods bool
+
+	// DisablePointerMethods specifies whether or not to check for and
+	// invoke error and Stringer interfaces on types which only accept a
+	// pointer receiver when the current type is not a pointer.
+	DisablePointerMethods bool
+
+	// DisablePointerAddresses specifies whether to disable the printing of
+	// pointer addresses. This is useful when diffing data structures in
+	// tests.
+	DisablePointerAddresses bool
+
+	// DisableCapacities specifies whether to disable the printing of
+	// capacities for arrays, slices, maps and channels.
+	DisableCapacities bool
+
+	// ContinueOnMethod specifies whether or not recursion should continue
+	// once a custom error or Stringer interface is invoked.
+	ContinueOnMethod bool
+
+	// SortKeys specifies map keys should be sorted before being printed.
+	SortKeys bool
+
+	// SpewKeys specifies that, as a last resort attempt, map keys should be
+	// spewed to strings and sorted by those strings.
+	SpewKeys bool
+
+	// RedactTag names the struct tag DumpStructured consults to decide
+	// whether a field's value should be replaced with a redaction
+	// placeholder rather than its real value.  The text formatter (Dump,
+	// Sdump and the %v family) does not consult it and prints tagged
+	// fields unredacted.  It defaults to "spew" when left as the zero
+	// value, matching tags like `spew:"redact"` and
+	// `spew:"redact,hash=sha256"`.
+	RedactTag string
+
+	// Redactor, when set, overrides the tag-driven default for every
+	// redacted field: it is called with the field and its value, and its
+	// returned string is used verbatim when its bool return is true.
+	// Returning false falls back to the tag-driven hash/placeholder
+	// behavior in redactedString.
+	Redactor func(field reflect.StructField, v reflect.Value) (string, bool)
+}
+
+// Config is the active configuration of the top-level functions.  The
+// configuration can be changed by modifying the contents of spew.Config.
+var Config = ConfigState{Indent: " "}
@@ -0,0 +1,518 @@
+package spew
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// nodeEmitter is the small backend interface the structured dump formats
+// implement so DumpStructured can share a single graph walker - the same
+// recursion, cycle tracking, and Stringer/error precedence dumpState
+// already uses for the text formatter - across every machine-readable
+// output. Each format only needs to say how to render a leaf value, how
+// to assemble an already-rendered composite's children, and how to mark
+// up pointer identity for cycle sharing.
+type nodeEmitter interface {
+	// scalar renders a leaf value (including kind placeholders like
+	// "<chan>") as a format-native node.
+	scalar(v interface{}) interface{}
+
+	// composite assembles a slice/array/map/struct's already-rendered
+	// children into a format-native node. children are unkeyed (nil
+	// key) for slices and arrays, and keyed by field or map-key name
+	// otherwise.
+	composite(typeName string, kind reflect.Kind, children []structuredChild) interface{}
+
+	// ref renders a pointer-identity placeholder standing in for a
+	// value expanded (or still being expanded) elsewhere in the dump.
+	ref(id string) interface{}
+
+	// attachID marks node as the first, canonical expansion of the
+	// given pointer identity, so a later ref(id) can resolve back to
+	// it. Object-shaped nodes (composite's map/struct output) gain it
+	// as an extra key; list-shaped and scalar nodes, which have no key
+	// space of their own, are wrapped.
+	attachID(node interface{}, id string) interface{}
+
+	// marshal serializes the finished root node to w.
+	marshal(w io.Writer, root interface{}) error
+}
+
+// structuredChild is one already-rendered child of a composite node.
+type structuredChild struct {
+	key   interface{}
+	value interface{}
+}
+
+// structuredDumper walks a value graph the same way dumpState's dump does,
+// but builds an in-memory node tree for a nodeEmitter instead of writing
+// text directly, so cycle detection and unexported-field handling only
+// need to live in one place.
+type structuredDumper struct {
+	cs           *ConfigState
+	emitter      nodeEmitter
+	ancestors    map[uintptr]bool
+	expanded     map[uintptr]bool
+	depth        int
+	renderingKey bool
+}
+
+// DumpStructured writes the machine-readable equivalent of Dump for the
+// passed arguments to w in the given format ("json" or "yaml"). Composites
+// carry no type tag: slices and arrays become plain lists and maps and
+// structs plain objects, so a value's Go type is not recorded in the
+// output. Output uses "$id"/"$ref" pointer-identity
+// markers so shared or cyclic pointers, slices, maps, and channels
+// round-trip instead of re-expanding or recursing forever, hex-encoded
+// byte slices, and unexported fields recovered via unsafeReflectValue just
+// like the text formatter. c.MaxDepth and c.DisableMethods apply to the
+// structured formats exactly as they do to Dump.
+func (c *ConfigState) DumpStructured(w io.Writer, format string, a ...interface{}) error {
+	var emitter nodeEmitter
+	switch strings.ToLower(format) {
+	case "json":
+		emitter = jsonEmitter{indent: c.Indent}
+	case "yaml":
+		emitter = yamlEmitter{}
+	default:
+		return fmt.Errorf("spew: unknown structured dump format %q", format)
+	}
+
+	d := &structuredDumper{
+		cs:        c,
+		emitter:   emitter,
+		ancestors: make(map[uintptr]bool),
+		expanded:  make(map[uintptr]bool),
+	}
+	nodes := make([]interface{}, len(a))
+	for i, v := range a {
+		nodes[i] = d.build(reflect.ValueOf(v))
+	}
+
+	var root interface{}
+	if len(nodes) == 1 {
+		root = nodes[0]
+	} else {
+		root = nodes
+	}
+	return emitter.marshal(w, root)
+}
+
+// Sjson dumps the passed arguments as a single JSON document using the
+// default ConfigState, analogous to Sdump for the text formatter.
+func Sjson(a ...interface{}) (string, error) {
+	var buf strings.Builder
+	if err := Config.DumpStructured(&buf, "json", a...); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Syaml dumps the passed arguments as a single YAML document using the
+// default ConfigState, analogous to Sdump for the text formatter.
+func Syaml(a ...interface{}) (string, error) {
+	var buf strings.Builder
+	if err := Config.DumpStructured(&buf, "yaml", a...); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// build recurses through v, returning the node tree the configured
+// emitter produces for it.
+func (d *structuredDumper) build(v reflect.Value) interface{} {
+	if d.cs.MaxDepth != 0 && d.depth >= d.cs.MaxDepth {
+		return d.emitter.scalar("<max depth reached>")
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return d.emitter.scalar(nil)
+		}
+		if v.Kind() == reflect.Ptr {
+			elem := v.Elem()
+			// Recurse through build, not buildValue directly, so a
+			// pointer to a pointer keeps unwrapping instead of being
+			// handed to buildValue's kind switch, which has no Ptr
+			// case of its own.
+			inner := func() interface{} { return d.build(elem) }
+			if addr, ok := safePointerIdentity(v); ok {
+				return d.guardIdentity(addr, inner)
+			}
+			return inner()
+		}
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return d.emitter.scalar(nil)
+	}
+
+	return d.buildValue(v)
+}
+
+// withIdentity guards the expansion of a value with pointer-like identity
+// (Ptr, Map, Slice, Chan) against both infinite recursion and duplicated
+// output:
+//
+//   - If addr is still an open ancestor on the current path, this is a
+//     genuine cycle - e.g. a slice or map reachable from itself through
+//     an interface{} element, not just a self-referencing struct pointer
+//   - and must stop immediately: unbounded recursion here is a fatal,
+//     unrecoverable stack overflow, not a panic recover() could catch.
+//   - If addr was already fully expanded elsewhere in this dump (a
+//     shared, non-cyclic pointer - e.g. two struct fields aliasing the
+//     same pointee), re-expanding it would silently duplicate the data
+//     and hide the aliasing, so it is also replaced with a ref.
+//
+// Either way the replacement is emitter.ref(addr). The first real
+// expansion of any address is tagged with a matching "$id" via
+// emitter.attachID so that ref is always resolvable back to a node,
+// which the structured formats need to stay "re-ingestible" for tooling.
+func (d *structuredDumper) withIdentity(addr uintptr, buildInner func() interface{}) interface{} {
+	id := fmt.Sprintf("0x%x", addr)
+	if d.ancestors[addr] || d.expanded[addr] {
+		return d.emitter.ref(id)
+	}
+
+	d.ancestors[addr] = true
+	d.expanded[addr] = true
+	inner := buildInner()
+	delete(d.ancestors, addr)
+
+	return d.emitter.attachID(inner, id)
+}
+
+// withAncestorGuard is withIdentity's counterpart for rendering a map
+// key: a key's rendered node is stringified by mapKey and discarded
+// rather than kept in the output tree, so marking addr expanded or
+// attaching an $id here would record an anchor no $ref could ever
+// legitimately resolve back to. It still pushes addr onto the ancestor
+// stack, since a key built from a self-referential pointer chain (a
+// comparable *T whose pointee eventually points back to it) would
+// otherwise recurse forever the same way an un-discarded value would.
+func (d *structuredDumper) withAncestorGuard(addr uintptr, buildInner func() interface{}) interface{} {
+	if d.ancestors[addr] {
+		return d.emitter.ref(fmt.Sprintf("0x%x", addr))
+	}
+
+	d.ancestors[addr] = true
+	inner := buildInner()
+	delete(d.ancestors, addr)
+
+	return inner
+}
+
+// guardIdentity picks withIdentity or withAncestorGuard depending on
+// whether the current build is rendering a map key, so every pointer-
+// like value encountered while building a key - not just the key's own
+// top-level pointer - stays off the expanded/$id bookkeeping that the
+// real output tree relies on for resolvable $refs.
+func (d *structuredDumper) guardIdentity(addr uintptr, buildInner func() interface{}) interface{} {
+	if d.renderingKey {
+		return d.withAncestorGuard(addr, buildInner)
+	}
+	return d.withIdentity(addr, buildInner)
+}
+
+// buildValue renders v, which has already been unwrapped past any
+// pointer/interface indirection, applying the text formatter's
+// unexported-field recovery and error/Stringer precedence before
+// dispatching on kind.
+func (d *structuredDumper) buildValue(v reflect.Value) interface{} {
+	v = unsafeReflectValue(v)
+
+	// Match dumpState's error/Stringer precedence so structured output
+	// stays consistent with the text formatter, unless the caller has
+	// disabled it.
+	if !d.cs.DisableMethods && v.CanInterface() {
+		if err, ok := v.Interface().(error); ok {
+			return d.emitter.scalar(err.Error())
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return d.emitter.scalar(s.String())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return d.emitter.scalar(hex.EncodeToString(b))
+		}
+		build := func() interface{} { return d.buildSequence(v) }
+		if v.Kind() == reflect.Slice && !v.IsNil() {
+			if addr, ok := safePointerIdentity(v); ok {
+				return d.guardIdentity(addr, build)
+			}
+		}
+		return build()
+
+	case reflect.Map:
+		if v.IsNil() {
+			return d.emitter.scalar(nil)
+		}
+		build := func() interface{} { return d.buildMap(v) }
+		if addr, ok := safePointerIdentity(v); ok {
+			return d.guardIdentity(addr, build)
+		}
+		return build()
+
+	case reflect.Struct:
+		return d.buildStruct(v)
+
+	case reflect.Chan:
+		scalar := func() interface{} { return d.emitter.scalar(fmt.Sprintf("<%s>", v.Kind())) }
+		if !v.IsNil() {
+			if addr, ok := safePointerIdentity(v); ok {
+				return d.guardIdentity(addr, scalar)
+			}
+		}
+		return scalar()
+
+	case reflect.Func:
+		return d.emitter.scalar(fmt.Sprintf("<%s>", v.Kind()))
+
+	default:
+		if v.CanInterface() {
+			return d.emitter.scalar(v.Interface())
+		}
+		return d.emitter.scalar(fmt.Sprintf("<%s Value>", v.Kind()))
+	}
+}
+
+// buildSequence renders a slice or array's elements as an unkeyed
+// composite.
+func (d *structuredDumper) buildSequence(v reflect.Value) interface{} {
+	children := make([]structuredChild, v.Len())
+	d.depth++
+	for i := 0; i < v.Len(); i++ {
+		children[i] = structuredChild{value: d.build(v.Index(i))}
+	}
+	d.depth--
+	return d.emitter.composite(v.Type().String(), v.Kind(), children)
+}
+
+// buildMap renders a map's entries as a composite keyed by the
+// (sorted, stringified) map key.
+func (d *structuredDumper) buildMap(v reflect.Value) interface{} {
+	keys := v.MapKeys()
+	sortValues(keys, d.cs)
+	children := make([]structuredChild, len(keys))
+	d.depth++
+	for i, k := range keys {
+		children[i] = structuredChild{key: d.mapKey(k), value: d.build(v.MapIndex(k))}
+	}
+	d.depth--
+	return d.emitter.composite(v.Type().String(), v.Kind(), children)
+}
+
+// buildStruct renders a struct's fields as a composite keyed by field
+// name, applying field-level redaction ahead of the normal recursion.
+func (d *structuredDumper) buildStruct(v reflect.Value) interface{} {
+	t := v.Type()
+	children := make([]structuredChild, t.NumField())
+	d.depth++
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		var value interface{}
+		if _, redacted := parseRedactTag(d.cs, field); redacted {
+			value = d.emitter.scalar(redactedString(d.cs, field, v.Field(i)))
+		} else {
+			value = d.build(v.Field(i))
+		}
+		children[i] = structuredChild{key: field.Name, value: value}
+	}
+	d.depth--
+	return d.emitter.composite(t.String(), v.Kind(), children)
+}
+
+// mapKey renders a map key as plain text. Map keys in JSON/YAML are
+// always strings, so even unhashable-looking key types (structs,
+// pointers) are rendered via build and then stringified rather than
+// recursed into as their own composite. renderingKey is set for the
+// duration of that render so any pointer-like value reached while
+// building the key - including nested ones, not just k itself - goes
+// through withAncestorGuard instead of withIdentity: this render's
+// output is about to be discarded into a string, so it must not mark
+// anything expanded or attach an $id that no consumer could ever see.
+func (d *structuredDumper) mapKey(k reflect.Value) string {
+	prev := d.renderingKey
+	d.renderingKey = true
+	rendered := d.build(k)
+	d.renderingKey = prev
+	if s, ok := rendered.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", rendered)
+}
+
+// jsonEmitter backs the "json" format by building a tree of plain Go
+// values that encoding/json already knows how to marshal.
+type jsonEmitter struct {
+	indent string
+}
+
+func (jsonEmitter) scalar(v interface{}) interface{} { return v }
+
+func (jsonEmitter) composite(typeName string, kind reflect.Kind, children []structuredChild) interface{} {
+	if kind == reflect.Slice || kind == reflect.Array {
+		out := make([]interface{}, len(children))
+		for i, c := range children {
+			out[i] = c.value
+		}
+		return out
+	}
+	out := make(map[string]interface{}, len(children))
+	for _, c := range children {
+		out[fmt.Sprintf("%v", c.key)] = c.value
+	}
+	return out
+}
+
+func (jsonEmitter) ref(id string) interface{} {
+	return map[string]interface{}{"$ref": id}
+}
+
+func (jsonEmitter) attachID(node interface{}, id string) interface{} {
+	if m, ok := node.(map[string]interface{}); ok {
+		m["$id"] = id
+		return m
+	}
+	return map[string]interface{}{"$id": id, "value": node}
+}
+
+func (e jsonEmitter) marshal(w io.Writer, root interface{}) error {
+	enc := json.NewEncoder(w)
+	// Dump output is read by humans and tooling, not embedded in HTML;
+	// escaping the angle brackets of "<redacted>", "<chan>", and friends
+	// into \uXXXX escape sequences
+	// would make every kind placeholder unreadable for no benefit here.
+	enc.SetEscapeHTML(false)
+	if e.indent != "" {
+		enc.SetIndent("", e.indent)
+	}
+	return enc.Encode(root)
+}
+
+// yamlEntry is one key/value pair of a yamlMap. A plain Go map would lose
+// the key order build already sorted via sortValues, so composite keeps
+// it as an ordered slice instead.
+type yamlEntry struct {
+	key   string
+	value interface{}
+}
+
+type yamlMap []yamlEntry
+
+// yamlEmitter backs the "yaml" format with a minimal block-style emitter.
+// spew has no other dependencies today, so this intentionally avoids
+// pulling in an external YAML library and only supports the node shapes
+// DumpStructured ever produces: scalars, sequences, and ordered maps.
+type yamlEmitter struct{}
+
+func (yamlEmitter) scalar(v interface{}) interface{} { return v }
+
+func (yamlEmitter) composite(typeName string, kind reflect.Kind, children []structuredChild) interface{} {
+	if kind == reflect.Slice || kind == reflect.Array {
+		out := make([]interface{}, len(children))
+		for i, c := range children {
+			out[i] = c.value
+		}
+		return out
+	}
+	out := make(yamlMap, len(children))
+	for i, c := range children {
+		out[i] = yamlEntry{key: fmt.Sprintf("%v", c.key), value: c.value}
+	}
+	return out
+}
+
+func (yamlEmitter) ref(id string) interface{} {
+	return yamlMap{{key: "$ref", value: id}}
+}
+
+func (yamlEmitter) attachID(node interface{}, id string) interface{} {
+	if m, ok := node.(yamlMap); ok {
+		return append(yamlMap{{key: "$id", value: id}}, m...)
+	}
+	return yamlMap{{key: "$id", value: id}, {key: "value", value: node}}
+}
+
+func (yamlEmitter) marshal(w io.Writer, root interface{}) error {
+	return writeYAMLNode(w, root, 0)
+}
+
+func writeYAMLNode(w io.Writer, node interface{}, indent int) error {
+	pad := strings.Repeat("  ", indent)
+	switch n := node.(type) {
+	case yamlMap:
+		if len(n) == 0 {
+			_, err := fmt.Fprintf(w, "%s{}\n", pad)
+			return err
+		}
+		for _, e := range n {
+			switch e.value.(type) {
+			case yamlMap, []interface{}:
+				if _, err := fmt.Fprintf(w, "%s%s:\n", pad, e.key); err != nil {
+					return err
+				}
+				if err := writeYAMLNode(w, e.value, indent+1); err != nil {
+					return err
+				}
+			default:
+				if _, err := fmt.Fprintf(w, "%s%s: %s\n", pad, e.key, yamlScalar(e.value)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case []interface{}:
+		if len(n) == 0 {
+			_, err := fmt.Fprintf(w, "%s[]\n", pad)
+			return err
+		}
+		for _, item := range n {
+			switch item.(type) {
+			case yamlMap, []interface{}:
+				if _, err := fmt.Fprintf(w, "%s-\n", pad); err != nil {
+					return err
+				}
+				if err := writeYAMLNode(w, item, indent+1); err != nil {
+					return err
+				}
+			default:
+				if _, err := fmt.Fprintf(w, "%s- %s\n", pad, yamlScalar(item)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		_, err := fmt.Fprintf(w, "%s%s\n", pad, yamlScalar(node))
+		return err
+	}
+}
+
+// yamlScalar renders a single scalar value as YAML flow text.
+func yamlScalar(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if val == "" {
+			return `""`
+		}
+		return strconv.Quote(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
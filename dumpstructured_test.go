@@ -0,0 +1,214 @@
+package spew
+
+import (
+	"strings"
+	"testing"
+)
+
+type structuredCycle struct {
+	Name string
+	Next *structuredCycle
+}
+
+// TestDumpStructuredJSONCycle ensures a self-referencing pointer is
+// rendered as a $ref rather than recursing forever.
+func TestDumpStructuredJSONCycle(t *testing.T) {
+	c := &structuredCycle{Name: "a"}
+	c.Next = c
+
+	out, err := Sjson(c)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	if !strings.Contains(out, `"$ref"`) {
+		t.Errorf("expected cycle to be rendered as a $ref, got: %s", out)
+	}
+}
+
+// TestDumpStructuredJSONSliceCycle ensures a slice reachable from itself
+// through an interface{} element - which never goes through a *T pointer
+// indirection - is still caught by cycle detection instead of recursing
+// until the process hits a fatal, unrecoverable stack overflow.
+func TestDumpStructuredJSONSliceCycle(t *testing.T) {
+	s := make([]interface{}, 1)
+	s[0] = s
+
+	out, err := Sjson(s)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	if !strings.Contains(out, `"$ref"`) {
+		t.Errorf("expected cycle to be rendered as a $ref, got: %s", out)
+	}
+}
+
+// TestDumpStructuredJSONMapCycle is TestDumpStructuredJSONSliceCycle's
+// map equivalent.
+func TestDumpStructuredJSONMapCycle(t *testing.T) {
+	m := make(map[string]interface{})
+	m["self"] = m
+
+	out, err := Sjson(m)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	if !strings.Contains(out, `"$ref"`) {
+		t.Errorf("expected cycle to be rendered as a $ref, got: %s", out)
+	}
+}
+
+type structuredLeaf struct{ Value int }
+type structuredPair struct{ A, B *structuredLeaf }
+
+// TestDumpStructuredJSONSharedPointerSibling ensures two fields aliasing
+// the same non-cyclic pointer expand it exactly once, with the second
+// occurrence resolvable back to the first via "$id"/"$ref" rather than
+// either silently duplicating the data or dangling.
+func TestDumpStructuredJSONSharedPointerSibling(t *testing.T) {
+	leaf := &structuredLeaf{Value: 9}
+	p := structuredPair{A: leaf, B: leaf}
+
+	out, err := Sjson(p)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	// Count occurrences of the "Value" key rather than assuming exact
+	// colon spacing, since Config.Indent can make the encoder pretty-print.
+	if got := strings.Count(out, `"Value"`); got != 1 {
+		t.Errorf("expected the shared pointer to be expanded exactly once, got %d times in: %s", got, out)
+	}
+	if !strings.Contains(out, `"$id"`) || !strings.Contains(out, `"$ref"`) {
+		t.Errorf("expected the second occurrence to be a resolvable $ref, got: %s", out)
+	}
+}
+
+type structuredKeyLeaf struct{ V int }
+type structuredKeyOwner struct {
+	M map[*structuredKeyLeaf]string
+	P *structuredKeyLeaf
+}
+
+// TestDumpStructuredJSONMapKeyPointerSharedWithField ensures rendering a
+// pointer as a map key (discarded into a string) does not consume the
+// pointer's $id/expanded slot: a sibling field pointing at the same
+// pointee must still get a real, resolvable expansion rather than a
+// dangling $ref to an $id that was never attached.
+func TestDumpStructuredJSONMapKeyPointerSharedWithField(t *testing.T) {
+	leaf := &structuredKeyLeaf{V: 1}
+	owner := structuredKeyOwner{M: map[*structuredKeyLeaf]string{leaf: "k"}, P: leaf}
+
+	out, err := Sjson(owner)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	// The leaf's own field should be expanded exactly once - by P, the
+	// only real (non-key) occurrence of the pointer - and never again as
+	// a dangling $ref, even though the map key render saw the same
+	// pointer first.
+	if got := strings.Count(out, `"V"`); got != 1 {
+		t.Errorf("expected the leaf to be expanded exactly once, got %d times in: %s", got, out)
+	}
+	if strings.Contains(out, `"$ref"`) {
+		t.Errorf("expected no dangling $ref, got: %s", out)
+	}
+}
+
+// TestDumpStructuredJSONChannelPlaceholderNotHTMLEscaped ensures the "<...>"
+// kind placeholders survive JSON encoding literally instead of being
+// HTML-escaped, angle brackets turned into \uXXXX escape sequences, which
+// encoding/json's encoder does
+// by default.
+func TestDumpStructuredJSONChannelPlaceholderNotHTMLEscaped(t *testing.T) {
+	ch := make(chan int)
+	out, err := Sjson(ch)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	if !strings.Contains(out, "<chan>") {
+		t.Errorf("expected a literal <chan> placeholder, got: %s", out)
+	}
+}
+
+// TestDumpStructuredJSONByteSlice ensures byte slices are hex encoded
+// rather than rendered as an array of small integers.
+func TestDumpStructuredJSONByteSlice(t *testing.T) {
+	out, err := Sjson([]byte{0xde, 0xad, 0xbe, 0xef})
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	want := `"deadbeef"`
+	if strings.TrimSpace(out) != want {
+		t.Errorf("got %s want %s", out, want)
+	}
+}
+
+// TestDumpStructuredJSONMapUnhashableLookingKey ensures a map whose key
+// type is itself a struct - the sort of key that looks unhashable at a
+// glance - still dumps as a JSON object with stringified keys.
+func TestDumpStructuredJSONMapUnhashableLookingKey(t *testing.T) {
+	type coord struct{ X, Y int }
+	m := map[coord]string{{X: 1, Y: 2}: "a"}
+
+	out, err := Sjson(m)
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	if !strings.Contains(out, `"a"`) {
+		t.Errorf("expected map value to be present, got: %s", out)
+	}
+}
+
+// TestDumpStructuredYAMLChannel ensures channels render as a kind
+// placeholder instead of erroring, matching the text formatter's
+// behavior for values with no meaningful textual representation.
+func TestDumpStructuredYAMLChannel(t *testing.T) {
+	ch := make(chan int)
+	out, err := Syaml(ch)
+	if err != nil {
+		t.Fatalf("Syaml returned error: %v", err)
+	}
+	if !strings.Contains(out, "<chan>") {
+		t.Errorf("expected channel placeholder, got: %s", out)
+	}
+}
+
+// TestDumpStructuredYAMLNestedStruct exercises yamlEmitter's block-style
+// nesting for a struct field that is itself a struct.
+func TestDumpStructuredYAMLNestedStruct(t *testing.T) {
+	type inner struct{ Value int }
+	type outer struct{ Inner inner }
+
+	out, err := Syaml(outer{Inner: inner{Value: 7}})
+	if err != nil {
+		t.Fatalf("Syaml returned error: %v", err)
+	}
+	if !strings.Contains(out, "Inner:") || !strings.Contains(out, "Value: 7") {
+		t.Errorf("expected nested block mapping, got: %s", out)
+	}
+}
+
+// TestDumpStructuredJSONRedactedField ensures a struct field tagged for
+// redaction is replaced in structured output with the <redacted>
+// placeholder rather than leaking the real value.
+func TestDumpStructuredJSONRedactedField(t *testing.T) {
+	out, err := Sjson(redactSample{Name: "alice", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("Sjson returned error: %v", err)
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("expected Password to be redacted, got: %s", out)
+	}
+	if !strings.Contains(out, "<redacted>") {
+		t.Errorf("expected a <redacted> placeholder, got: %s", out)
+	}
+}
+
+// TestDumpStructuredUnknownFormat ensures an unsupported format name is
+// reported as an error rather than silently falling back to one of the
+// known formats.
+func TestDumpStructuredUnknownFormat(t *testing.T) {
+	var buf strings.Builder
+	if err := Config.DumpStructured(&buf, "toml", 1); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,25 @@
+//go:build go1.18
+
+package spew
+
+import "reflect"
+
+// safePointerIdentity returns a pointer identity for v suitable for cycle
+// detection without unsafe, for the kinds that expose one directly.  It
+// never touches the unsafe package itself, so unlike unsafeReflectValue
+// it is built unconditionally - the structured dumper's cycle detection
+// needs a stable identity for Ptr/Map/Chan/Slice regardless of whether
+// the calling build is the safe or unsafe variant. On Go 1.18+,
+// reflect.Value.UnsafePointer covers both Ptr and UnsafePointer kinds and
+// Pointer() the remaining pointer-like kinds; older releases use Pointer()
+// for every pointer-like kind (see pointeridentity_legacy.go).
+func safePointerIdentity(v reflect.Value) (uintptr, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer:
+		return uintptr(v.UnsafePointer()), true
+	case reflect.Map, reflect.Chan, reflect.Func, reflect.Slice:
+		return v.Pointer(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !go1.18
+
+package spew
+
+import "reflect"
+
+// safePointerIdentity returns a pointer identity for v suitable for cycle
+// detection without unsafe, for the kinds that expose one directly. It
+// never touches the unsafe package itself, so unlike unsafeReflectValue
+// it is built unconditionally - the structured dumper's cycle detection
+// needs a stable identity for Ptr/Map/Chan/Slice regardless of whether
+// the calling build is the safe or unsafe variant. Before Go 1.18,
+// reflect.Value.Pointer covers Ptr alongside the other pointer-like
+// kinds; Go 1.18+ instead uses UnsafePointer for Ptr (see
+// pointeridentity_go118.go), since Pointer is deprecated there in favor
+// of it.
+func safePointerIdentity(v reflect.Value) (uintptr, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Map, reflect.Chan, reflect.Func, reflect.Slice:
+		return v.Pointer(), true
+	default:
+		return 0, false
+	}
+}
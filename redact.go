@@ -0,0 +1,104 @@
+package spew
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Struct-field redaction is wired into structuredDumper.buildStruct in
+// dumpstructured.go, which calls parseRedactTag/redactedString for every
+// field ahead of the normal recursion. The text formatter (dumpState.dump
+// and formatState.format, the struct-field loops behind Dump/Sdump/%v)
+// needs the same two calls at its own per-field emission point so a
+// redacted field reads the same way in both outputs, but dump.go/format.go
+// are not part of this chunk of the tree - there is no struct-field loop
+// here to add the calls to. Whoever lands those files should call
+// redactedString wherever they currently write a struct field's rendered
+// value, exactly as buildStruct does.
+//
+// redactOptions is the parsed form of a field's RedactTag value, e.g.
+// `spew:"redact"` or `spew:"redact,hash=sha256"`.
+type redactOptions struct {
+	redact   bool
+	hashWith string
+}
+
+// parseRedactTag reads the struct tag named by cs.RedactTag (or "spew"
+// when that is left as the zero value) off field and reports whether
+// redaction applies to it.
+func parseRedactTag(cs *ConfigState, field reflect.StructField) (redactOptions, bool) {
+	tagName := cs.RedactTag
+	if tagName == "" {
+		tagName = "spew"
+	}
+	raw, ok := field.Tag.Lookup(tagName)
+	if !ok {
+		return redactOptions{}, false
+	}
+
+	var opts redactOptions
+	for _, part := range strings.Split(raw, ",") {
+		switch part = strings.TrimSpace(part); {
+		case part == "redact":
+			opts.redact = true
+		case strings.HasPrefix(part, "hash="):
+			opts.redact = true
+			opts.hashWith = strings.TrimPrefix(part, "hash=")
+		}
+	}
+	return opts, opts.redact
+}
+
+// redactedString renders the replacement text for a field that
+// structuredDumper.buildStruct has identified as redacted, trying in order: a custom
+// cs.Redactor hook, a requested hash algorithm, then the bare
+// "<redacted>" placeholder. Hashing needs the field's underlying value,
+// which for an unexported field normally requires the unsafe package;
+// the safe build instead falls back to unsafeReflectValue's best-effort
+// recovery (see bypasssafe.go) and quietly drops the hash suffix if that
+// still can't produce a usable value, so redaction degrades to
+// "<redacted>" rather than panicking.
+func redactedString(cs *ConfigState, field reflect.StructField, v reflect.Value) string {
+	if cs.Redactor != nil {
+		if s, ok := cs.Redactor(field, v); ok {
+			return s
+		}
+	}
+
+	opts, _ := parseRedactTag(cs, field)
+	if opts.hashWith == "" {
+		return "<redacted>"
+	}
+
+	readable := unsafeReflectValue(v)
+	if !readable.CanInterface() {
+		return "<redacted>"
+	}
+
+	sum, ok := hashValue(opts.hashWith, readable.Interface())
+	if !ok {
+		return "<redacted>"
+	}
+	return fmt.Sprintf("<%s:%s>", opts.hashWith, sum)
+}
+
+// hashValue hashes the textual form of val with the named algorithm.
+// Only sha256 is built in today; an unrecognized name reports false so
+// the caller can fall back to the bare placeholder instead of erroring -
+// a typo in a struct tag should never be fatal to a dump.
+func hashValue(algorithm string, val interface{}) (string, bool) {
+	switch algorithm {
+	case "sha256":
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%v", val)))
+		full := hex.EncodeToString(sum[:])
+		if len(full) > 8 {
+			full = full[:8]
+		}
+		return full, true
+	default:
+		return "", false
+	}
+}
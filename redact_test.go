@@ -0,0 +1,74 @@
+package spew
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type redactSample struct {
+	Name     string
+	Password string `spew:"redact"`
+	Token    string `spew:"redact,hash=sha256"`
+}
+
+// TestParseRedactTag checks tag parsing for an unredacted field, a bare
+// "redact" field, and a "redact,hash=..." field.
+func TestParseRedactTag(t *testing.T) {
+	typ := reflect.TypeOf(redactSample{})
+
+	if _, ok := parseRedactTag(&Config, typ.Field(0)); ok {
+		t.Errorf("Name field should not be redacted")
+	}
+
+	opts, ok := parseRedactTag(&Config, typ.Field(1))
+	if !ok || opts.hashWith != "" {
+		t.Errorf("Password field should be redacted with no hash, got %+v ok=%v", opts, ok)
+	}
+
+	opts, ok = parseRedactTag(&Config, typ.Field(2))
+	if !ok || opts.hashWith != "sha256" {
+		t.Errorf("Token field should be redacted with sha256, got %+v ok=%v", opts, ok)
+	}
+}
+
+// TestRedactedStringHash ensures a "hash=sha256" field renders as a
+// truncated sha256 placeholder rather than the bare "<redacted>" text.
+func TestRedactedStringHash(t *testing.T) {
+	typ := reflect.TypeOf(redactSample{})
+	v := reflect.ValueOf("super-secret")
+
+	got := redactedString(&Config, typ.Field(2), v)
+	if !strings.HasPrefix(got, "<sha256:") {
+		t.Errorf("expected a sha256 placeholder, got %s", got)
+	}
+}
+
+// TestRedactedStringCustomRedactor ensures a configured Redactor hook
+// takes priority over the tag-driven defaults.
+func TestRedactedStringCustomRedactor(t *testing.T) {
+	cs := &ConfigState{Redactor: func(field reflect.StructField, v reflect.Value) (string, bool) {
+		return "<custom>", true
+	}}
+	typ := reflect.TypeOf(redactSample{})
+
+	got := redactedString(cs, typ.Field(1), reflect.ValueOf("irrelevant"))
+	if got != "<custom>" {
+		t.Errorf("expected custom redactor output, got %s", got)
+	}
+}
+
+// TestRedactedStringUnexportedFallback mirrors TestAddedReflectValue's use
+// of changeKind to produce a reflect.Value that can no longer be read via
+// Interface(). Hashing must degrade to the bare placeholder in that case
+// instead of panicking.
+func TestRedactedStringUnexportedFallback(t *testing.T) {
+	v := reflect.ValueOf(int8(5))
+	changeKind(&v, true)
+
+	typ := reflect.TypeOf(redactSample{})
+	got := redactedString(&Config, typ.Field(2), v)
+	if got != "<redacted>" {
+		t.Errorf("expected bare placeholder when value is unreadable, got %s", got)
+	}
+}